@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/dkolbly/cli"
+
+	"github.com/dkolbly/hipchat-cli/hipchat"
+)
+
+// sendMessage builds the Notifier --backend selects and delivers msg
+// through it. This is the common tail of every send* command's Action.
+func sendMessage(c *cli.Context, msg *hipchat.Message) error {
+	notifier, err := buildNotifier(c)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := notifier.Notify(context.Background(), msg); err != nil {
+		log.Error("POST failed: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+// buildNotifier returns the hipchat.Notifier that --backend selects,
+// configured from the flags relevant to that backend.
+func buildNotifier(c *cli.Context) (hipchat.Notifier, error) {
+	switch backend := c.String("backend"); backend {
+	case "", "hipchat":
+		return buildHipChatNotifier(c)
+
+	case "slack":
+		url, transport, err := webhookBackendArgs(c)
+		if err != nil {
+			return nil, err
+		}
+		return &hipchat.SlackNotifier{WebhookURL: url, Transport: transport}, nil
+
+	case "mattermost":
+		url, transport, err := webhookBackendArgs(c)
+		if err != nil {
+			return nil, err
+		}
+		return &hipchat.MattermostNotifier{WebhookURL: url, Transport: transport}, nil
+
+	case "msteams":
+		url, transport, err := webhookBackendArgs(c)
+		if err != nil {
+			return nil, err
+		}
+		return &hipchat.MSTeamsNotifier{WebhookURL: url, Transport: transport}, nil
+
+	case "webhook":
+		url, transport, err := webhookBackendArgs(c)
+		if err != nil {
+			return nil, err
+		}
+		return &hipchat.WebhookNotifier{URL: url, Transport: transport}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown --backend %q", backend)
+	}
+}
+
+func buildHipChatNotifier(c *cli.Context) (hipchat.Notifier, error) {
+	token := c.String("token")
+	if token == "" {
+		return nil, fmt.Errorf("--token is required for --backend hipchat")
+	}
+	if !c.IsSet("room") {
+		return nil, fmt.Errorf("--room is required for --backend hipchat")
+	}
+
+	transport, err := buildTransport(c)
+	if err != nil {
+		return nil, err
+	}
+
+	client := hipchat.NewClient(token)
+	client.Transport = transport
+	client.Debugf = log.Debug
+
+	// Only override the client's retry defaults if the user actually
+	// passed the flag; otherwise the client falls back to
+	// DefaultMaxRetries/DefaultRetryBase. An explicit --max-retries 0 is
+	// passed through as a pointer to 0, which disables retrying.
+	if c.IsSet("max-retries") {
+		maxRetries := c.Int("max-retries")
+		client.MaxRetries = &maxRetries
+	}
+	if c.IsSet("retry-base") {
+		retryBase := c.Duration("retry-base")
+		client.RetryBase = &retryBase
+	}
+
+	return &hipchat.RoomNotifier{Client: client, RoomID: c.Int("room")}, nil
+}
+
+func webhookBackendArgs(c *cli.Context) (url string, transport http.RoundTripper, err error) {
+	url = c.String("webhook-url")
+	if url == "" {
+		return "", nil, fmt.Errorf("--webhook-url is required for --backend %s", c.String("backend"))
+	}
+	transport, err = buildTransport(c)
+	if err != nil {
+		return "", nil, err
+	}
+	return url, transport, nil
+}
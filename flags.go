@@ -0,0 +1,87 @@
+package main
+
+import (
+	"github.com/dkolbly/cli"
+
+	"github.com/dkolbly/hipchat-cli/hipchat"
+)
+
+// connectionFlags are the flags shared by every command that ends up
+// sending a Message through buildNotifier: how to authenticate/address
+// the destination, transport tuning (--insecure/--proxy/--ca-cert),
+// retry tuning, and --backend/--webhook-url. Keeping these in one place
+// means a new cross-cutting flag only needs to be added once, and every
+// send command picks it up automatically.
+func connectionFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "debug",
+			Aliases: []string{"d"},
+			Usage:   "enable debug messages",
+		},
+		&cli.StringFlag{
+			Name:    "token",
+			Aliases: []string{"t"},
+			EnvVars: []string{"HIPCHAT_TOKEN"},
+			Usage:   "API token (required for --backend hipchat)",
+		},
+		&cli.IntFlag{
+			Name:    "room",
+			Aliases: []string{"r"},
+			EnvVars: []string{"HIPCHAT_ROOM_ID"},
+			Usage:   "room ID (required for --backend hipchat)",
+		},
+		&cli.StringFlag{
+			Name:    "from",
+			Aliases: []string{"f"},
+			EnvVars: []string{"HIPCHAT_FROM"},
+			Usage:   "from name",
+		},
+		&cli.StringFlag{
+			Name:    "color",
+			Aliases: []string{"c"},
+			EnvVars: []string{"HIPCHAT_COLOR"},
+			Usage:   "message color (yellow, red, green, purple, gray or random)",
+			Value:   "yellow",
+		},
+		&cli.BoolFlag{
+			Name:    "notify",
+			Aliases: []string{"n"},
+			Usage:   "Trigger notification for people in the room",
+		},
+		&cli.BoolFlag{
+			Name:    "insecure",
+			Aliases: []string{"k"},
+			Usage:   "Don't validate SSL credentials",
+		},
+		&cli.StringFlag{
+			Name:    "proxy",
+			EnvVars: []string{"HTTPS_PROXY", "HIPCHAT_PROXY"},
+			Usage:   "URL of an HTTPS proxy to route requests through",
+		},
+		&cli.StringFlag{
+			Name:  "ca-cert",
+			Usage: "PATH to a PEM file of CA certificates to trust, in addition to the system roots",
+		},
+		&cli.IntFlag{
+			Name:  "max-retries",
+			Usage: "how many times to retry on 429/5xx responses",
+			Value: hipchat.DefaultMaxRetries,
+		},
+		&cli.DurationFlag{
+			Name:  "retry-base",
+			Usage: "base delay for exponential backoff between retries",
+			Value: hipchat.DefaultRetryBase,
+		},
+		&cli.StringFlag{
+			Name:  "backend",
+			Usage: "where to send the notification: hipchat, slack, mattermost, msteams, webhook",
+			Value: "hipchat",
+		},
+		&cli.StringFlag{
+			Name:    "webhook-url",
+			EnvVars: []string{"HIPCHAT_CLI_WEBHOOK_URL"},
+			Usage:   "destination URL (required for --backend slack/mattermost/msteams/webhook)",
+		},
+	}
+}
@@ -2,33 +2,25 @@ package main
 
 import (
 	"bytes"
-	"encoding/json"
-	"errors"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"html"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"regexp"
 
 	"github.com/dkolbly/cli"
 	"github.com/dkolbly/logging"
 	"github.com/dkolbly/logging/pretty"
+
+	"github.com/dkolbly/hipchat-cli/hipchat"
 )
 
 var log = logging.New("hipchat-cli")
 
-var ErrPostFailed = errors.New("posting message failed")
-var ErrInsecureNotImplemented = errors.New("--insecure is not yet implemented")
-
-type Message struct {
-	From          string `json:"from,omitempty"`
-	Message       string `json:"message"`
-	Color         string `json:"color"`
-	MessageFormat string `json:"message_format"`
-	Notify        bool   `json:"notify"`
-}
-
 func main() {
 
 	app := &cli.App{
@@ -36,7 +28,7 @@ func main() {
 		Version: "1.0.0",
 	}
 
-	app.Commands = append(app.Commands, sendCmd)
+	app.Commands = append(app.Commands, sendCmd, sendCardCmd)
 
 	app.Run(os.Args)
 }
@@ -45,59 +37,26 @@ var sendCmd = &cli.Command{
 	Name:   "send",
 	Usage:  "send a message to a room",
 	Action: doSend,
-	Flags: []cli.Flag{
-		&cli.BoolFlag{
-			Name:    "debug",
-			Aliases: []string{"d"},
-			Usage:   "enable debug messages",
-		},
-		&cli.StringFlag{
-			Name:     "token",
-			Aliases:  []string{"t"},
-			EnvVars:  []string{"HIPCHAT_TOKEN"},
-			Required: true,
-			Usage:    "API token",
-		},
-		&cli.IntFlag{
-			Name:     "room",
-			Aliases:  []string{"r"},
-			EnvVars:  []string{"HIPCHAT_ROOM_ID"},
-			Required: true,
-			Usage:    "room ID",
-		},
-		&cli.StringFlag{
-			Name:    "from",
-			Aliases: []string{"f"},
-			EnvVars: []string{"HIPCHAT_FROM"},
-			Usage:   "from name",
-		},
-		&cli.StringFlag{
-			Name:    "color",
-			Aliases: []string{"c"},
-			EnvVars: []string{"HIPCHAT_COLOR"},
-			Usage:   "message color (yellow, red, green, purple, gray or random)",
-			Value:   "yellow",
-		},
+	Flags: append(connectionFlags(),
 		&cli.StringFlag{
 			Name:    "message",
 			Aliases: []string{"m"},
 			Usage:   "the message to send (default: from stdin)",
 		},
 		&cli.BoolFlag{
-			Name:    "notify",
-			Aliases: []string{"n"},
-			Usage:   "Trigger notification for people in the room",
+			Name:  "html",
+			Usage: "input is already in HTML format; don't transform",
 		},
 		&cli.BoolFlag{
-			Name:    "insecure",
-			Aliases: []string{"k"},
-			Usage:   "Don't validate SSL credentials",
+			Name:    "markdown",
+			Aliases: []string{"M"},
+			Usage:   "input is CommonMark; render to the HTML subset HipChat understands (mutually exclusive with --html)",
 		},
-		&cli.BoolFlag{
-			Name:  "html",
-			Usage: "input is already in HTML format; don't transform",
+		&cli.StringFlag{
+			Name:  "card-file",
+			Usage: "attach the HipChat card read from FILE (or stdin if FILE is \"-\")",
 		},
-	},
+	),
 }
 
 func doSend(c *cli.Context) error {
@@ -105,14 +64,6 @@ func doSend(c *cli.Context) error {
 		pretty.Debug()
 	}
 
-	apiServer := "api.hipchat.com"
-	roomID := c.Int("room")
-	token := c.String("token")
-
-	url := fmt.Sprintf("https://%s/v2/room/%d/notification",
-		apiServer,
-		roomID)
-
 	var text string
 	if c.IsSet("message") {
 		text = c.String("message")
@@ -124,16 +75,26 @@ func doSend(c *cli.Context) error {
 		text = string(inp)
 	}
 
+	if c.Bool("html") && c.Bool("markdown") {
+		return fmt.Errorf("--html and --markdown are mutually exclusive")
+	}
+
 	// we always send HTML to HipChat; the difference is in how we
 	// interpret our input.  If the --html flag is specified, we
-	// pass it through unchanged.  Otherwise, we do some basic
-	// processing
+	// pass it through unchanged.  If --markdown is specified, we
+	// render CommonMark down to HipChat's HTML subset.  Otherwise, we
+	// do some basic processing
 	format := "html"
-	if !c.Bool("html") {
+	switch {
+	case c.Bool("html"):
+		// already HTML; pass through unchanged
+	case c.Bool("markdown"):
+		text = string(processMarkdown([]byte(text)))
+	default:
 		text = string(processPlainText([]byte(text)))
 	}
 
-	msg := &Message{
+	msg := &hipchat.Message{
 		Color:         c.String("color"),
 		Message:       text,
 		MessageFormat: format,
@@ -143,50 +104,61 @@ func doSend(c *cli.Context) error {
 		msg.From = c.String("from")
 	}
 
-	buf, err := json.Marshal(msg)
-	if err != nil {
-		log.Fatal(err)
+	if cardFile := c.String("card-file"); cardFile != "" {
+		card, err := loadCard(cardFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		msg.Card = card
 	}
 
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(buf))
-	if err != nil {
-		log.Fatal(err)
-	}
+	return sendMessage(c, msg)
+}
 
-	req.Header.Set("Content-Length", fmt.Sprintf("%d", len(buf)))
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+// buildTransport constructs an http.RoundTripper reflecting the
+// --insecure, --proxy and --ca-cert flags. It clones
+// http.DefaultTransport rather than mutating it so unrelated callers in
+// the same process are unaffected.
+func buildTransport(c *cli.Context) (http.RoundTripper, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
 
 	if c.Bool("insecure") {
-		// TODO implement --insecure; it requires setting up a
-		// http.Transport with an appropriate TLSClientConfig
-		return ErrInsecureNotImplemented
-	}
-
-	rsp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		log.Fatal(err)
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{}
+		}
+		t.TLSClientConfig.InsecureSkipVerify = true
 	}
-	defer rsp.Body.Close()
 
-	entity, err := ioutil.ReadAll(rsp.Body)
-	if err != nil {
-		log.Fatalf("Could not read response entity: %s", err)
+	if proxy := c.String("proxy"); proxy != "" {
+		u, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --proxy URL: %s", err)
+		}
+		t.Proxy = http.ProxyURL(u)
 	}
 
-	switch rsp.StatusCode {
-	case http.StatusOK, http.StatusNoContent:
-		log.Debug("Success %s; response headers:", rsp.Status)
-		for k, v := range rsp.Header {
-			log.Debug("%s := %q", k, v)
+	if caCert := c.String("ca-cert"); caCert != "" {
+		pemBytes, err := ioutil.ReadFile(caCert)
+		if err != nil {
+			return nil, fmt.Errorf("could not read --ca-cert: %s", err)
 		}
-
-	default:
-		log.Error("POST failed: %s\n%s", rsp.Status, entity)
-		return ErrPostFailed
+		// Add to the system roots rather than replacing them, so
+		// --ca-cert can point at an internal CA for a HipChat Server
+		// install while still trusting api.hipchat.com's public CA.
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pemBytes) {
+			return nil, fmt.Errorf("no certificates found in --ca-cert %s", caCert)
+		}
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{}
+		}
+		t.TLSClientConfig.RootCAs = pool
 	}
 
-	return nil
+	return t, nil
 }
 
 // urlRe is a modified form(*) of the @gruber v2 URL regex from
@@ -207,28 +179,76 @@ func doSend(c *cli.Context) error {
 //                                           ^ see, I just did it
 //
 
-var urlRe = regexp.MustCompile(`(?i)\b((?:https?:(?:/{1,3}|[a-z0-9%]))(?:[^\s()<>]+|\(([^\s()<>]+|(\([^\s()<>]+\)))*\))+(?:\(([^\s()<>]+|(\([^\s()<>]+\)))*\)|[^\s` + "`" + `!()\[\]{};:'".,<>?«»“”‘’]))`)
+const urlBody = `\b((?:https?:(?:/{1,3}|[a-z0-9%]))(?:[^\s()<>]+|\(([^\s()<>]+|(\([^\s()<>]+\)))*\))+(?:\(([^\s()<>]+|(\([^\s()<>]+\)))*\)|[^\s` + "`" + `!()\[\]{};:'".,<>?«»“”‘’]))`
+
+var urlRe = regexp.MustCompile(`(?i)` + urlBody)
+
+// tokenRe walks plain text recognizing, in priority order, backtick
+// code spans, URLs, @mentions (including the broadcast @all/@here,
+// which this pattern already matches like any other mention) and
+// #room references. Running all four as a single alternation, rather
+// than as sequential regex passes, means a match consumes its input so
+// a later pass can't reinterpret (and corrupt) text already turned
+// into a link -- in particular, a mention or URL inside a code span is
+// consumed as part of that span and never looked at again.
+//
+// Note: Go's RE2 engine picks the first alternative that matches at a
+// given position, not the longest, so "all"/"here" must not be listed
+// as separate branches ahead of the general username pattern -- doing
+// so previously caused "@allison" to match only "@all", silently
+// turning a specific mention into a room-wide broadcast.
+var tokenRe = regexp.MustCompile(`(?i)(?P<code>` + "`" + `[^` + "`" + `\n]*` + "`" + `)` +
+	`|(?P<url>` + urlBody + `)` +
+	`|(?P<mention>@[A-Za-z][A-Za-z0-9_.-]{0,49})` +
+	`|(?P<room>#[A-Za-z0-9_-]+)`)
+
+var tokenReGroupNames = tokenRe.SubexpNames()
 
 // processPlainText takes a message in plain text as input and applies
-// certain transformations to HTML-ify it
+// certain transformations to HTML-ify it: URLs, @mentions and #room
+// references are turned into links, and everything else is escaped.
+// Text inside a `code span` is rendered as <code> but otherwise left
+// alone, so a mention or URL someone is citing literally (e.g. "run
+// `@allison`'s script") doesn't get linkified.
 func processPlainText(src []byte) []byte {
 
 	out := &bytes.Buffer{}
 
-	plain := func(chunk []byte) {
-		out.WriteString(html.EscapeString(string(chunk)))
-	}
-
-	for {
-		loc := urlRe.FindIndex(src)
+	for len(src) > 0 {
+		loc := tokenRe.FindSubmatchIndex(src)
 		if loc == nil {
-			plain(src)
+			out.WriteString(html.EscapeString(string(src)))
 			break
 		}
-		plain(src[:loc[0]])
-		url := src[loc[0]:loc[1]]
-		fmt.Fprintf(out, `<a href="%s">%s</a>`, url, url)
+
+		out.WriteString(html.EscapeString(string(src[:loc[0]])))
+		token := src[loc[0]:loc[1]]
+
+		switch matchedGroup(tokenReGroupNames, loc) {
+		case "code":
+			fmt.Fprintf(out, `<code>%s</code>`, html.EscapeString(string(token[1:len(token)-1])))
+		case "url":
+			fmt.Fprintf(out, `<a href="%s">%s</a>`, token, token)
+		case "mention":
+			fmt.Fprintf(out, `<a href="https://www.hipchat.com/users/%s">%s</a>`, token, token)
+		case "room":
+			fmt.Fprintf(out, `<a href="https://www.hipchat.com/rooms/%s">%s</a>`, token, token)
+		}
+
 		src = src[loc[1]:]
 	}
+
 	return out.Bytes()
 }
+
+// matchedGroup returns the name of the named subexpression that
+// participated in the match described by loc, as returned by
+// Regexp.FindSubmatchIndex.
+func matchedGroup(names []string, loc []int) string {
+	for i, name := range names {
+		if name != "" && loc[2*i] != -1 {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,48 @@
+package hipchat
+
+// Card is a HipChat v2 "card" attachment, which renders as a richer
+// notification than plain message text. See
+// https://developer.atlassian.com/hipchat/guide/sending-messages for
+// the full schema; this covers the fields callers actually use.
+type Card struct {
+	ID          string          `json:"id,omitempty"`
+	Style       string          `json:"style"`
+	Title       string          `json:"title"`
+	Description string          `json:"description,omitempty"`
+	Thumbnail   *CardIcon       `json:"thumbnail,omitempty"`
+	Activity    *CardActivity   `json:"activity,omitempty"`
+	Attributes  []CardAttribute `json:"attributes,omitempty"`
+	URL         string          `json:"url,omitempty"`
+}
+
+// CardIcon is an image reference, used for a Card's thumbnail.
+type CardIcon struct {
+	URL string `json:"url"`
+}
+
+// CardActivity describes how the card should render in a room's
+// history view.
+type CardActivity struct {
+	HTML string    `json:"html"`
+	Icon *CardIcon `json:"icon,omitempty"`
+}
+
+// CardAttribute is one label/value row shown underneath a card's
+// description.
+type CardAttribute struct {
+	Label string        `json:"label,omitempty"`
+	Value CardAttrValue `json:"value"`
+}
+
+// CardAttrValue is the value half of a CardAttribute.
+type CardAttrValue struct {
+	Label string `json:"label"`
+}
+
+// Card styles accepted by the HipChat v2 API.
+const (
+	CardStyleApplication = "application"
+	CardStyleLink        = "link"
+	CardStyleMedia       = "media"
+	CardStyleFile        = "file"
+)
@@ -0,0 +1,199 @@
+// Package hipchat is a minimal client for the HipChat v2 API.
+//
+// It exists so that programs other than hipchat-cli can send room
+// notifications and private messages without shelling out to the CLI.
+package hipchat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var ErrPostFailed = errors.New("posting message failed")
+
+// DefaultBaseURL is used when a Client does not specify its own BaseURL.
+const DefaultBaseURL = "https://api.hipchat.com"
+
+// DefaultMaxRetries and DefaultRetryBase are used when a Client leaves
+// MaxRetries/RetryBase at their zero value.
+const (
+	DefaultMaxRetries = 3
+	DefaultRetryBase  = 500 * time.Millisecond
+	maxRetryDelay     = 30 * time.Second
+)
+
+// Message is the body of a HipChat v2 notification.
+type Message struct {
+	From          string `json:"from,omitempty"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+	MessageFormat string `json:"message_format"`
+	Notify        bool   `json:"notify"`
+	Card          *Card  `json:"card,omitempty"`
+}
+
+// Client talks to the HipChat v2 API using a token obtained from a
+// room's or user's API access tab.
+type Client struct {
+	Token string
+
+	// BaseURL is the root of the HipChat API, e.g. https://api.hipchat.com
+	// for HipChat Cloud, or the base of a HipChat Server install. Defaults
+	// to DefaultBaseURL if empty.
+	BaseURL string
+
+	// Transport is used for all requests issued by the Client. If nil,
+	// http.DefaultTransport is used.
+	Transport http.RoundTripper
+
+	// MaxRetries is how many additional attempts to make after a 429 or
+	// 5xx response before giving up. A nil MaxRetries defaults to
+	// DefaultMaxRetries; a pointer to 0 disables retrying entirely.
+	MaxRetries *int
+
+	// RetryBase is the base delay used for the exponential backoff
+	// between retries, when the response doesn't carry a Retry-After
+	// header. A nil RetryBase defaults to DefaultRetryBase.
+	RetryBase *time.Duration
+
+	// Debugf, if set, is called with diagnostic information about each
+	// request/response, including the X-RateLimit-Remaining headroom.
+	Debugf func(format string, args ...interface{})
+}
+
+// NewClient returns a Client authenticating with token and talking to
+// HipChat Cloud.
+func NewClient(token string) *Client {
+	return &Client{
+		Token:   token,
+		BaseURL: DefaultBaseURL,
+	}
+}
+
+func (c *Client) baseURL() string {
+	if c.BaseURL != "" {
+		return c.BaseURL
+	}
+	return DefaultBaseURL
+}
+
+func (c *Client) httpClient() *http.Client {
+	return &http.Client{Transport: c.Transport}
+}
+
+// PostRoomNotification sends msg to the room identified by roomID.
+func (c *Client) PostRoomNotification(ctx context.Context, roomID int, msg *Message) error {
+	url := fmt.Sprintf("%s/v2/room/%d/notification", c.baseURL(), roomID)
+	return c.post(ctx, url, msg)
+}
+
+// SendPrivateMessage sends msg to the user identified by userID, which
+// may be a user ID, email address, or @mention name.
+func (c *Client) SendPrivateMessage(ctx context.Context, userID string, msg *Message) error {
+	url := fmt.Sprintf("%s/v2/user/%s/message", c.baseURL(), userID)
+	return c.post(ctx, url, msg)
+}
+
+func (c *Client) post(ctx context.Context, url string, msg *Message) error {
+	buf, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	maxRetries := DefaultMaxRetries
+	if c.MaxRetries != nil {
+		maxRetries = *c.MaxRetries
+	}
+	retryBase := DefaultRetryBase
+	if c.RetryBase != nil {
+		retryBase = *c.RetryBase
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(buf))
+		if err != nil {
+			return err
+		}
+
+		req.Header.Set("Content-Length", fmt.Sprintf("%d", len(buf)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.Token))
+
+		rsp, err := c.httpClient().Do(req)
+		if err != nil {
+			return err
+		}
+
+		entity, err := ioutil.ReadAll(rsp.Body)
+		rsp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("could not read response entity: %s", err)
+		}
+
+		c.debugf("POST %s: %s (X-RateLimit-Remaining: %s)",
+			url, rsp.Status, rsp.Header.Get("X-RateLimit-Remaining"))
+
+		switch {
+		case rsp.StatusCode == http.StatusOK || rsp.StatusCode == http.StatusNoContent:
+			return nil
+
+		case rsp.StatusCode == http.StatusTooManyRequests || rsp.StatusCode >= 500:
+			if attempt >= maxRetries {
+				return fmt.Errorf("%w: %s\n%s", ErrPostFailed, rsp.Status, entity)
+			}
+			delay := retryDelay(rsp.Header, retryBase, attempt)
+			c.debugf("retrying in %s (attempt %d/%d)", delay, attempt+1, maxRetries)
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+		default:
+			return fmt.Errorf("%w: %s\n%s", ErrPostFailed, rsp.Status, entity)
+		}
+	}
+}
+
+func (c *Client) debugf(format string, args ...interface{}) {
+	if c.Debugf != nil {
+		c.Debugf(format, args...)
+	}
+}
+
+// retryDelay determines how long to wait before the next retry. It
+// honors a Retry-After header (seconds or HTTP date) if present,
+// otherwise backs off as base*2^attempt with +/-20% jitter, capped at
+// maxRetryDelay.
+func retryDelay(h http.Header, base time.Duration, attempt int) time.Duration {
+	if ra := h.Get("Retry-After"); ra != "" {
+		if secs, err := strconv.Atoi(ra); err == nil {
+			return time.Duration(secs) * time.Second
+		}
+		if t, err := http.ParseTime(ra); err == nil {
+			if d := time.Until(t); d > 0 {
+				return d
+			}
+		}
+	}
+
+	d := base * time.Duration(1<<uint(attempt))
+	jitter := time.Duration((rand.Float64()*0.4 - 0.2) * float64(d))
+	d += jitter
+
+	if d > maxRetryDelay {
+		d = maxRetryDelay
+	}
+	if d < 0 {
+		d = 0
+	}
+	return d
+}
@@ -0,0 +1,122 @@
+package hipchat
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetryDelayHonorsRetryAfterSeconds(t *testing.T) {
+	h := http.Header{}
+	h.Set("Retry-After", "5")
+	got := retryDelay(h, time.Second, 0)
+	if got != 5*time.Second {
+		t.Errorf("retryDelay with Retry-After: 5 = %s, want 5s", got)
+	}
+}
+
+func TestRetryDelayHonorsRetryAfterDate(t *testing.T) {
+	future := time.Now().Add(10 * time.Second)
+	h := http.Header{}
+	h.Set("Retry-After", future.UTC().Format(http.TimeFormat))
+	got := retryDelay(h, time.Second, 0)
+	if got <= 0 || got > 10*time.Second {
+		t.Errorf("retryDelay with future Retry-After date = %s, want in (0s, 10s]", got)
+	}
+}
+
+func TestRetryDelayBacksOffExponentiallyAndCaps(t *testing.T) {
+	h := http.Header{}
+
+	prev := time.Duration(0)
+	for attempt := 0; attempt < 3; attempt++ {
+		d := retryDelay(h, 100*time.Millisecond, attempt)
+		if d < 0 {
+			t.Fatalf("retryDelay(attempt=%d) = %s, want >= 0", attempt, d)
+		}
+		if d < prev/2 {
+			t.Fatalf("retryDelay(attempt=%d) = %s, want roughly increasing from previous %s", attempt, d, prev)
+		}
+		prev = d
+	}
+
+	if d := retryDelay(h, time.Hour, 10); d > maxRetryDelay {
+		t.Errorf("retryDelay didn't cap at maxRetryDelay: got %s, want <= %s", d, maxRetryDelay)
+	}
+}
+
+// TestPostRetriesOn5xxThenSucceeds drives Client.post against a real
+// httptest server that fails twice before succeeding, verifying the
+// retry loop actually retries on 5xx until the request succeeds.
+func TestPostRetriesOn5xxThenSucceeds(t *testing.T) {
+	var attempts int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	base := time.Millisecond
+	c := &Client{BaseURL: srv.URL, RetryBase: &base}
+	if err := c.PostRoomNotification(context.Background(), 1, &Message{Message: "hi"}); err != nil {
+		t.Fatalf("PostRoomNotification: %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("got %d attempts, want 3 (2 failures + 1 success)", attempts)
+	}
+}
+
+// TestPostMaxRetriesZeroDisablesRetrying verifies that an explicit
+// MaxRetries of 0 is honored and not silently treated as "unset".
+func TestPostMaxRetriesZeroDisablesRetrying(t *testing.T) {
+	var attempts int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	zero := 0
+	base := time.Millisecond
+	c := &Client{BaseURL: srv.URL, MaxRetries: &zero, RetryBase: &base}
+	if err := c.PostRoomNotification(context.Background(), 1, &Message{Message: "hi"}); err == nil {
+		t.Fatal("expected an error from a persistently failing server")
+	}
+	if attempts != 1 {
+		t.Errorf("got %d attempts with MaxRetries=0, want exactly 1 (no retries)", attempts)
+	}
+}
+
+// TestPostHonorsContextCancellation verifies that cancelling ctx during
+// the inter-retry backoff aborts the retry loop instead of blocking
+// through it.
+func TestPostHonorsContextCancellation(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	base := time.Hour
+	c := &Client{BaseURL: srv.URL, RetryBase: &base}
+
+	done := make(chan error, 1)
+	go func() { done <- c.PostRoomNotification(ctx, 1, &Message{Message: "hi"}) }()
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("expected an error after context cancellation")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("PostRoomNotification did not return after context cancellation")
+	}
+}
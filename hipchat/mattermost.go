@@ -0,0 +1,18 @@
+package hipchat
+
+import (
+	"context"
+	"net/http"
+)
+
+// MattermostNotifier posts to a Mattermost incoming webhook URL.
+// Mattermost speaks the same payload shape as Slack's incoming
+// webhooks, so this just reuses the Slack payload.
+type MattermostNotifier struct {
+	WebhookURL string
+	Transport  http.RoundTripper
+}
+
+func (n *MattermostNotifier) Notify(ctx context.Context, msg *Message) error {
+	return postJSON(ctx, n.Transport, n.WebhookURL, slackPayloadFor(msg))
+}
@@ -0,0 +1,82 @@
+package hipchat
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+)
+
+// htmlToSlackMrkdwn converts the HTML subset HipChat renders (see the
+// --markdown CLI flag, which produces it) into Slack's mrkdwn. It is
+// the inverse of that conversion, used by the Slack and Mattermost
+// backends.
+func htmlToSlackMrkdwn(src string) string {
+	z := xhtml.NewTokenizer(strings.NewReader(src))
+	out := &bytes.Buffer{}
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case xhtml.ErrorToken:
+			return out.String()
+
+		case xhtml.TextToken:
+			out.WriteString(slackEscape(string(z.Text())))
+
+		case xhtml.StartTagToken, xhtml.EndTagToken, xhtml.SelfClosingTagToken:
+			writeMrkdwnTag(out, z, tt)
+		}
+	}
+}
+
+func writeMrkdwnTag(out *bytes.Buffer, z *xhtml.Tokenizer, tt xhtml.TokenType) {
+	name, hasAttr := z.TagName()
+	isEnd := tt == xhtml.EndTagToken
+
+	switch string(name) {
+	case "b", "strong":
+		out.WriteString("*")
+	case "i", "em":
+		out.WriteString("_")
+	case "code":
+		out.WriteString("`")
+	case "pre":
+		out.WriteString("```")
+	case "br":
+		out.WriteString("\n")
+	case "li":
+		if !isEnd {
+			out.WriteString("\n• ")
+		}
+	case "a":
+		if isEnd {
+			out.WriteString(">")
+		} else {
+			fmt.Fprintf(out, "<%s|", tagHref(z, hasAttr))
+		}
+	}
+}
+
+// slackEscape escapes the three characters Slack's mrkdwn format
+// requires escaped in text fields. The xhtml tokenizer hands TextToken
+// content back already unescaped, so without this the &/</> that
+// processPlainText/processMarkdown escaped on the way in would reach
+// Slack raw, letting Slack's own mrkdwn parser misinterpret them.
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func slackEscape(s string) string {
+	return slackEscaper.Replace(s)
+}
+
+func tagHref(z *xhtml.Tokenizer, hasAttr bool) string {
+	for hasAttr {
+		var key, val []byte
+		key, val, hasAttr = z.TagAttr()
+		if string(key) == "href" {
+			return string(val)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,30 @@
+package hipchat
+
+import "testing"
+
+func TestHTMLToSlackMrkdwn(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"<b>bold</b> and <i>italic</i>", "*bold* and _italic_"},
+		{"<code>a &lt; b &amp;&amp; b &gt; c</code>", "`a &lt; b &amp;&amp; b &gt; c`"},
+		{`<a href="https://example.com">link</a>`, "<https://example.com|link>"},
+		{"one<br>two", "one\ntwo"},
+	}
+
+	for _, c := range cases {
+		got := htmlToSlackMrkdwn(c.in)
+		if got != c.want {
+			t.Errorf("htmlToSlackMrkdwn(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestHTMLToSlackMrkdwnEscapesText(t *testing.T) {
+	got := htmlToSlackMrkdwn("a < b && b > c")
+	want := "a &lt; b &amp;&amp; b &gt; c"
+	if got != want {
+		t.Errorf("htmlToSlackMrkdwn(%q) = %q, want %q", "a < b && b > c", got, want)
+	}
+}
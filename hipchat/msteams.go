@@ -0,0 +1,51 @@
+package hipchat
+
+import (
+	"context"
+	"net/http"
+)
+
+// MSTeamsNotifier posts an Office 365 Connector "MessageCard" to a
+// Microsoft Teams incoming webhook URL.
+type MSTeamsNotifier struct {
+	WebhookURL string
+	Transport  http.RoundTripper
+}
+
+type teamsMessageCard struct {
+	Type       string `json:"@type"`
+	Context    string `json:"@context"`
+	ThemeColor string `json:"themeColor,omitempty"`
+	Title      string `json:"title,omitempty"`
+	Text       string `json:"text"`
+}
+
+func (n *MSTeamsNotifier) Notify(ctx context.Context, msg *Message) error {
+	card := teamsMessageCard{
+		Type:       "MessageCard",
+		Context:    "http://schema.org/extensions",
+		ThemeColor: colorToHex(msg.Color),
+		Title:      msg.From,
+		Text:       htmlToSlackMrkdwn(msg.Message),
+	}
+	return postJSON(ctx, n.Transport, n.WebhookURL, card)
+}
+
+// colorToHex maps a HipChat notification color to a hex themeColor for
+// the Teams MessageCard.
+func colorToHex(color string) string {
+	switch color {
+	case "green":
+		return "36a64f"
+	case "yellow":
+		return "daa038"
+	case "red":
+		return "d00000"
+	case "purple":
+		return "6b2e91"
+	case "gray":
+		return "888888"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,57 @@
+package hipchat
+
+import "testing"
+
+func TestColorToSlack(t *testing.T) {
+	cases := map[string]string{
+		"green":  "good",
+		"yellow": "warning",
+		"red":    "danger",
+		"purple": "",
+		"":       "",
+	}
+	for in, want := range cases {
+		if got := colorToSlack(in); got != want {
+			t.Errorf("colorToSlack(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestColorToHex(t *testing.T) {
+	cases := map[string]string{
+		"green":  "36a64f",
+		"yellow": "daa038",
+		"red":    "d00000",
+		"purple": "6b2e91",
+		"gray":   "888888",
+		"":       "",
+	}
+	for in, want := range cases {
+		if got := colorToHex(in); got != want {
+			t.Errorf("colorToHex(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSlackPayloadFor(t *testing.T) {
+	msg := &Message{
+		From:    "bob",
+		Color:   "red",
+		Message: "<b>hi</b>",
+	}
+	payload := slackPayloadFor(msg)
+
+	if payload.Username != "bob" {
+		t.Errorf("Username = %q, want %q", payload.Username, "bob")
+	}
+	if len(payload.Attachments) != 1 {
+		t.Fatalf("got %d attachments, want 1", len(payload.Attachments))
+	}
+	att := payload.Attachments[0]
+	if att.Color != "danger" {
+		t.Errorf("Color = %q, want %q", att.Color, "danger")
+	}
+	if want := "*hi*"; att.Text != want {
+		t.Errorf("Text = %q, want %q", att.Text, want)
+	}
+}
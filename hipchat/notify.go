@@ -0,0 +1,22 @@
+package hipchat
+
+import "context"
+
+// Notifier sends a Message somewhere — a HipChat room, a Slack/
+// Mattermost/Microsoft Teams webhook, or an arbitrary URL. Code built
+// around Message can swap where notifications land by swapping the
+// Notifier it's given, rather than rewriting the call site.
+type Notifier interface {
+	Notify(ctx context.Context, msg *Message) error
+}
+
+// RoomNotifier adapts a Client to Notifier for a single, fixed room.
+type RoomNotifier struct {
+	Client *Client
+	RoomID int
+}
+
+// Notify posts msg to the room the RoomNotifier was constructed with.
+func (n *RoomNotifier) Notify(ctx context.Context, msg *Message) error {
+	return n.Client.PostRoomNotification(ctx, n.RoomID, msg)
+}
@@ -0,0 +1,53 @@
+package hipchat
+
+import (
+	"context"
+	"net/http"
+)
+
+// SlackNotifier posts to a Slack incoming webhook URL.
+type SlackNotifier struct {
+	WebhookURL string
+	Transport  http.RoundTripper
+}
+
+type slackPayload struct {
+	Username    string            `json:"username,omitempty"`
+	Attachments []slackAttachment `json:"attachments,omitempty"`
+}
+
+type slackAttachment struct {
+	Color string `json:"color,omitempty"`
+	Text  string `json:"text"`
+}
+
+func (n *SlackNotifier) Notify(ctx context.Context, msg *Message) error {
+	return postJSON(ctx, n.Transport, n.WebhookURL, slackPayloadFor(msg))
+}
+
+func slackPayloadFor(msg *Message) slackPayload {
+	return slackPayload{
+		Username: msg.From,
+		Attachments: []slackAttachment{
+			{
+				Color: colorToSlack(msg.Color),
+				Text:  htmlToSlackMrkdwn(msg.Message),
+			},
+		},
+	}
+}
+
+// colorToSlack maps a HipChat notification color to the closest Slack
+// attachment color.
+func colorToSlack(color string) string {
+	switch color {
+	case "green":
+		return "good"
+	case "yellow":
+		return "warning"
+	case "red":
+		return "danger"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,53 @@
+package hipchat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// WebhookNotifier POSTs the Message JSON unchanged to an arbitrary URL.
+// It's the escape hatch for anything that isn't HipChat, Slack,
+// Mattermost or Microsoft Teams.
+type WebhookNotifier struct {
+	URL       string
+	Transport http.RoundTripper
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, msg *Message) error {
+	return postJSON(ctx, n.Transport, n.URL, msg)
+}
+
+// postJSON marshals payload and POSTs it to url, returning ErrPostFailed
+// wrapped with the response body on a non-2xx status.
+func postJSON(ctx context.Context, transport http.RoundTripper, url string, payload interface{}) error {
+	buf, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	rsp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	entity, err := ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		return fmt.Errorf("could not read response entity: %s", err)
+	}
+
+	if rsp.StatusCode/100 != 2 {
+		return fmt.Errorf("%w: %s\n%s", ErrPostFailed, rsp.Status, entity)
+	}
+	return nil
+}
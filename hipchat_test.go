@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProcessPlainTextMentions(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{
+			"@allison can you take a look?",
+			`<a href="https://www.hipchat.com/users/@allison">@allison</a> can you take a look?`,
+		},
+		{
+			"@alley, ping @here",
+			`<a href="https://www.hipchat.com/users/@alley">@alley</a>, ping <a href="https://www.hipchat.com/users/@here">@here</a>`,
+		},
+		{
+			"@hereford is a cheese, not a broadcast",
+			`<a href="https://www.hipchat.com/users/@hereford">@hereford</a> is a cheese, not a broadcast`,
+		},
+		{
+			"@all please deploy #ops-room now",
+			`<a href="https://www.hipchat.com/users/@all">@all</a> please deploy <a href="https://www.hipchat.com/rooms/#ops-room">#ops-room</a> now`,
+		},
+		{
+			"see http://example.com/@notamention for details",
+			`see <a href="http://example.com/@notamention">http://example.com/@notamention</a> for details`,
+		},
+	}
+
+	for _, c := range cases {
+		got := string(processPlainText([]byte(c.in)))
+		if got != c.want {
+			t.Errorf("processPlainText(%q):\n got:  %s\n want: %s", c.in, got, c.want)
+		}
+	}
+}
+
+func TestProcessPlainTextLeavesCodeSpansUntouched(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{
+			"run `@allison`'s script",
+			"run <code>@allison</code>&#39;s script",
+		},
+		{
+			"see `http://example.com` for the literal URL",
+			"see <code>http://example.com</code> for the literal URL",
+		},
+		{
+			"`#ops-room` is just an example, try @alley instead",
+			`<code>#ops-room</code> is just an example, try <a href="https://www.hipchat.com/users/@alley">@alley</a> instead`,
+		},
+	}
+
+	for _, c := range cases {
+		got := string(processPlainText([]byte(c.in)))
+		if got != c.want {
+			t.Errorf("processPlainText(%q):\n got:  %s\n want: %s", c.in, got, c.want)
+		}
+	}
+}
+
+func TestProcessPlainTextEscapesPlainText(t *testing.T) {
+	got := string(processPlainText([]byte("a < b && b > c")))
+	if !strings.Contains(got, "&lt;") || !strings.Contains(got, "&gt;") {
+		t.Errorf("expected HTML-escaped output, got %q", got)
+	}
+}
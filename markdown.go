@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/yuin/goldmark"
+	goldmarkhtml "github.com/yuin/goldmark/renderer/html"
+	xhtml "golang.org/x/net/html"
+)
+
+// allowedMarkdownTags is the subset of HTML tags HipChat v2 actually
+// renders. Everything else produced by the CommonMark renderer is
+// stripped, keeping the text content, with <p> handled specially below.
+var allowedMarkdownTags = map[string]bool{
+	"b": true, "i": true, "strong": true, "em": true, "a": true,
+	"code": true, "pre": true, "ul": true, "ol": true, "li": true, "br": true,
+}
+
+var markdownConverter = goldmark.New(
+	goldmark.WithRendererOptions(
+		goldmarkhtml.WithUnsafe(),
+	),
+)
+
+// processMarkdown runs src through a CommonMark parser and rewrites the
+// result down to the tag subset HipChat understands, auto-linkifying
+// any bare URLs left in the plain text.
+func processMarkdown(src []byte) []byte {
+	var buf bytes.Buffer
+	if err := markdownConverter.Convert(src, &buf); err != nil {
+		log.Error("markdown parse failed, falling back to plain text: %s", err)
+		return processPlainText(src)
+	}
+	return tameMarkdownHTML(buf.Bytes())
+}
+
+// tameMarkdownHTML walks the HTML goldmark produced and rewrites it to
+// only the tags HipChat renders. <p> doesn't nest sensibly in HipChat's
+// renderer, so paragraph breaks become <br><br> instead. Bare URLs in
+// text nodes (outside of <a> and code spans) are auto-linkified.
+func tameMarkdownHTML(src []byte) []byte {
+	z := xhtml.NewTokenizer(bytes.NewReader(src))
+	out := &bytes.Buffer{}
+	aDepth, codeDepth := 0, 0
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case xhtml.ErrorToken:
+			return out.Bytes()
+
+		case xhtml.TextToken:
+			text := z.Text()
+			if aDepth > 0 || codeDepth > 0 {
+				out.WriteString(xhtml.EscapeString(string(text)))
+			} else {
+				out.Write(autolinkText(text))
+			}
+
+		case xhtml.StartTagToken, xhtml.EndTagToken, xhtml.SelfClosingTagToken:
+			writeMarkdownTag(out, z, tt, &aDepth, &codeDepth)
+		}
+	}
+}
+
+func writeMarkdownTag(out *bytes.Buffer, z *xhtml.Tokenizer, tt xhtml.TokenType, aDepth, codeDepth *int) {
+	name, hasAttr := z.TagName()
+	tag := string(name)
+
+	if tag == "p" {
+		if tt == xhtml.EndTagToken {
+			out.WriteString("<br><br>")
+		}
+		return
+	}
+
+	if !allowedMarkdownTags[tag] {
+		return
+	}
+
+	switch tag {
+	case "a":
+		if tt == xhtml.StartTagToken {
+			*aDepth++
+		} else if tt == xhtml.EndTagToken {
+			*aDepth--
+		}
+	case "code", "pre":
+		if tt == xhtml.StartTagToken {
+			*codeDepth++
+		} else if tt == xhtml.EndTagToken {
+			*codeDepth--
+		}
+	}
+
+	switch tt {
+	case xhtml.EndTagToken:
+		fmt.Fprintf(out, "</%s>", tag)
+	default:
+		fmt.Fprintf(out, "<%s%s>", tag, tagAttrs(z, hasAttr))
+	}
+}
+
+// tagAttrs preserves the one attribute HipChat's allowed tags need: href on <a>.
+func tagAttrs(z *xhtml.Tokenizer, hasAttr bool) string {
+	var attrs string
+	for hasAttr {
+		var key, val []byte
+		key, val, hasAttr = z.TagAttr()
+		if string(key) == "href" {
+			attrs += fmt.Sprintf(` href="%s"`, xhtml.EscapeString(string(val)))
+		}
+	}
+	return attrs
+}
+
+// autolinkText wraps bare URLs in src with <a> tags, HTML-escaping the
+// rest, the same way processPlainText does for --html-less plain text.
+func autolinkText(src []byte) []byte {
+	out := &bytes.Buffer{}
+	for {
+		loc := urlRe.FindIndex(src)
+		if loc == nil {
+			out.WriteString(xhtml.EscapeString(string(src)))
+			break
+		}
+		out.WriteString(xhtml.EscapeString(string(src[:loc[0]])))
+		url := src[loc[0]:loc[1]]
+		fmt.Fprintf(out, `<a href="%s">%s</a>`, url, url)
+		src = src[loc[1]:]
+	}
+	return out.Bytes()
+}
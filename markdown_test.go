@@ -0,0 +1,53 @@
+package main
+
+import "testing"
+
+func TestTameMarkdownHTMLStripsDisallowedTags(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"<h1>title</h1>", "title"},
+		{"<p>one</p><p>two</p>", "one<br><br>two<br><br>"},
+		{`<a href="https://example.com">link</a>`, `<a href="https://example.com">link</a>`},
+		{"<b>bold</b>", "<b>bold</b>"},
+		{"<blockquote>quoted</blockquote>", "quoted"},
+	}
+
+	for _, c := range cases {
+		got := string(tameMarkdownHTML([]byte(c.in)))
+		if got != c.want {
+			t.Errorf("tameMarkdownHTML(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestTameMarkdownHTMLAutolinksBareURLsOutsideTags(t *testing.T) {
+	got := string(tameMarkdownHTML([]byte("see http://example.com for details")))
+	want := `see <a href="http://example.com">http://example.com</a> for details`
+	if got != want {
+		t.Errorf("tameMarkdownHTML bare URL = %q, want %q", got, want)
+	}
+}
+
+func TestTameMarkdownHTMLLeavesURLsInsideAnchorsAndCodeAlone(t *testing.T) {
+	got := string(tameMarkdownHTML([]byte(`<a href="http://example.com">http://example.com</a>`)))
+	want := `<a href="http://example.com">http://example.com</a>`
+	if got != want {
+		t.Errorf("URL inside <a> got double-linkified: got %q, want %q", got, want)
+	}
+
+	got = string(tameMarkdownHTML([]byte("<code>http://example.com</code>")))
+	want = "<code>http://example.com</code>"
+	if got != want {
+		t.Errorf("URL inside <code> got linkified: got %q, want %q", got, want)
+	}
+}
+
+func TestProcessMarkdownRendersCommonMarkToHipChatSubset(t *testing.T) {
+	got := string(processMarkdown([]byte("**bold** and `code`")))
+	want := "<strong>bold</strong> and <code>code</code><br><br>\n"
+	if got != want {
+		t.Errorf("processMarkdown(%q) = %q, want %q", "**bold** and `code`", got, want)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dkolbly/cli"
+	"github.com/dkolbly/logging/pretty"
+
+	"github.com/dkolbly/hipchat-cli/hipchat"
+)
+
+var sendCardCmd = &cli.Command{
+	Name:   "send-card",
+	Usage:  "send a rich card notification to a room",
+	Action: doSendCard,
+	Flags: append(connectionFlags(),
+		&cli.StringFlag{
+			Name:  "card-file",
+			Usage: "read the card definition as JSON from FILE (default: stdin)",
+		},
+		&cli.StringFlag{
+			Name:  "card-title",
+			Usage: "card title (ignored if --card-file is given)",
+		},
+		&cli.StringFlag{
+			Name:  "card-description",
+			Usage: "card description (ignored if --card-file is given)",
+		},
+		&cli.StringFlag{
+			Name:  "card-url",
+			Usage: "URL the card links to (ignored if --card-file is given)",
+		},
+		&cli.StringFlag{
+			Name:  "card-style",
+			Usage: "one of application, link, media, file (ignored if --card-file is given)",
+			Value: hipchat.CardStyleApplication,
+		},
+		&cli.StringFlag{
+			Name:  "card-thumbnail-url",
+			Usage: "thumbnail image URL (ignored if --card-file is given)",
+		},
+	),
+}
+
+func doSendCard(c *cli.Context) error {
+	if c.Bool("debug") {
+		pretty.Debug()
+	}
+
+	var card *hipchat.Card
+	if c.IsSet("card-file") || !c.IsSet("card-title") {
+		var err error
+		card, err = loadCard(c.String("card-file"))
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		card = cardFromFlags(c)
+	}
+
+	msg := &hipchat.Message{
+		Message:       card.Title,
+		Color:         c.String("color"),
+		MessageFormat: "html",
+		Notify:        c.Bool("notify"),
+		Card:          card,
+	}
+	if c.IsSet("from") {
+		msg.From = c.String("from")
+	}
+
+	return sendMessage(c, msg)
+}
+
+// loadCard reads a JSON-encoded Card from path, or from stdin if path
+// is empty or "-".
+func loadCard(path string) (*hipchat.Card, error) {
+	var r io.Reader
+	if path == "" || path == "-" {
+		r = os.Stdin
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not open --card-file: %s", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var card hipchat.Card
+	if err := json.NewDecoder(r).Decode(&card); err != nil {
+		return nil, fmt.Errorf("could not parse card definition: %s", err)
+	}
+	return &card, nil
+}
+
+// cardFromFlags builds a Card from the --card-* convenience flags.
+func cardFromFlags(c *cli.Context) *hipchat.Card {
+	card := &hipchat.Card{
+		Style:       c.String("card-style"),
+		Title:       c.String("card-title"),
+		Description: c.String("card-description"),
+		URL:         c.String("card-url"),
+	}
+	if thumb := c.String("card-thumbnail-url"); thumb != "" {
+		card.Thumbnail = &hipchat.CardIcon{URL: thumb}
+	}
+	return card
+}